@@ -0,0 +1,84 @@
+package retle
+
+import (
+	"context"
+	"errors"
+	"net"
+)
+
+// Action tells Retry2 what to do after a call returned an error.
+type Action int
+
+const (
+	// ActionRetry retries the call.
+	ActionRetry Action = iota
+	// ActionFail stops retrying and returns the error.
+	ActionFail
+	// ActionSucceed stops retrying and returns nil, treating the error as recovered.
+	ActionSucceed
+)
+
+// Classifier decides which Action to take for an error returned by the function passed to
+// Retry2.
+type Classifier func(err error) Action
+
+// WithClassifier sets the Classifier used by Retry2 to decide whether an error is retryable.
+func WithClassifier(c Classifier) Option {
+	return func(e *ExpTimer) {
+		e.classifier = c
+	}
+}
+
+// permanentError marks an error as one that must never be retried.
+type permanentError struct {
+	err error
+}
+
+// Permanent wraps err so that Retry2 always classifies it as ActionFail, regardless of the
+// configured Classifier.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// classify resolves the Action for err, honoring Permanent before falling back to the
+// configured Classifier and finally to ActionRetry.
+func (e *ExpTimer) classify(err error) Action {
+	var permanent *permanentError
+	if errors.As(err, &permanent) {
+		return ActionFail
+	}
+	if e.classifier != nil {
+		return e.classifier(err)
+	}
+	return ActionRetry
+}
+
+// ClassifyNetTimeout retries errors that implement net.Error and report Timeout() == true.
+func ClassifyNetTimeout(err error) Action {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return ActionRetry
+	}
+	return ActionFail
+}
+
+// ClassifyNoCancel wraps next so that context.Canceled is never retried.
+func ClassifyNoCancel(next Classifier) Classifier {
+	return func(err error) Action {
+		if errors.Is(err, context.Canceled) {
+			return ActionFail
+		}
+		return next(err)
+	}
+}
@@ -0,0 +1,154 @@
+package retle
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shumon84/retle/retletest"
+)
+
+type fakeNetError struct {
+	timeout bool
+}
+
+func (e *fakeNetError) Error() string   { return "fake net error" }
+func (e *fakeNetError) Timeout() bool   { return e.timeout }
+func (e *fakeNetError) Temporary() bool { return e.timeout }
+
+func TestClassifyNetTimeout(t *testing.T) {
+	if action := ClassifyNetTimeout(&fakeNetError{timeout: true}); action != ActionRetry {
+		t.Fatalf("action(%v) != ActionRetry", action)
+	}
+	if action := ClassifyNetTimeout(&fakeNetError{timeout: false}); action != ActionFail {
+		t.Fatalf("action(%v) != ActionFail", action)
+	}
+	if action := ClassifyNetTimeout(errors.New("not a net.Error")); action != ActionFail {
+		t.Fatalf("action(%v) != ActionFail", action)
+	}
+}
+
+func TestClassifyNoCancel(t *testing.T) {
+	classifier := ClassifyNoCancel(func(error) Action { return ActionRetry })
+
+	if action := classifier(context.Canceled); action != ActionFail {
+		t.Fatalf("action(%v) != ActionFail", action)
+	}
+	if action := classifier(errors.New("other error")); action != ActionRetry {
+		t.Fatalf("action(%v) != ActionRetry", action)
+	}
+}
+
+func TestExpTimer_Retry2(t *testing.T) {
+	testCases := []struct {
+		name string
+		run  func(t *testing.T)
+	}{
+		{
+			name: "Permanentでラップされたエラーはリトライされない",
+			run: func(t *testing.T) {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+				defer cancel()
+
+				expTimer := NewExpTimer(time.Millisecond, 1.0)
+				expectErr := errors.New("sample error")
+				calls := 0
+				err := expTimer.Retry2(ctx, func() error {
+					calls++
+					return Permanent(expectErr)
+				})
+				if !errors.Is(err, expectErr) {
+					t.Fatalf("err(%v) is not expectErr", err)
+				}
+				if calls != 1 {
+					t.Fatalf("calls(%d) != 1", calls)
+				}
+			},
+		},
+		{
+			name: "classifierがActionRetryを返す間リトライする",
+			run: func(t *testing.T) {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+				defer cancel()
+
+				retryLimit := 3
+				calls := 0
+				expTimer := NewExpTimer(time.Millisecond, 1.0, WithClassifier(func(error) Action {
+					return ActionRetry
+				}))
+				err := expTimer.Retry2(ctx, func() error {
+					calls++
+					if calls > retryLimit {
+						return nil
+					}
+					return errors.New("transient error")
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if calls != retryLimit+1 {
+					t.Fatalf("calls(%d) != %d", calls, retryLimit+1)
+				}
+			},
+		},
+		{
+			name: "classifierがActionSucceedを返すとnilを返す",
+			run: func(t *testing.T) {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+				defer cancel()
+
+				expTimer := NewExpTimer(time.Millisecond, 1.0, WithClassifier(func(error) Action {
+					return ActionSucceed
+				}))
+				err := expTimer.Retry2(ctx, func() error {
+					return errors.New("ignorable error")
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, testCase.run)
+	}
+}
+
+func TestRetry2(t *testing.T) {
+	fakeClock := retletest.NewFakeClock(time.Unix(0, 0))
+	durationTimer := DefaultExpTimer() // 実際に発生するはずのスリープ時間を計算するための時計
+
+	retryLimit := 3
+	calls := 0
+	done := make(chan error, 1)
+	go func() {
+		done <- Retry2(context.Background(), func() error {
+			calls++
+			if calls > retryLimit {
+				return nil
+			}
+			return errors.New("transient error")
+		}, WithClock(fakeClock), WithClassifier(func(error) Action {
+			return ActionRetry
+		}))
+	}()
+
+	for i := 0; i < retryLimit; i++ {
+		fakeClock.BlockUntil(1)
+		fakeClock.Advance(durationTimer.NextDuration())
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Retry2が完了しませんでした")
+	}
+	if calls != retryLimit+1 {
+		t.Fatalf("calls(%d) != %d", calls, retryLimit+1)
+	}
+}
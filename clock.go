@@ -0,0 +1,36 @@
+package retle
+
+import "time"
+
+// Clock abstracts the passage of time so ExpTimer can be driven by a fake clock in tests instead
+// of sleeping in real time.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// Sleep blocks for d.
+	Sleep(d time.Duration)
+	// After returns a channel that receives the current time after d has passed.
+	After(d time.Duration) <-chan time.Time
+}
+
+// WithClock sets the Clock used by Sleep and Retry, defaulting to the real wall clock.
+func WithClock(clock Clock) Option {
+	return func(e *ExpTimer) {
+		e.clock = clock
+	}
+}
+
+// realClock is the default Clock, backed by the time package.
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
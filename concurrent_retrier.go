@@ -0,0 +1,66 @@
+package retle
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ConcurrentRetrier coordinates backoff across goroutines that share a single downstream
+// dependency: once any caller reports a failure via Failed, every caller blocked in Throttle
+// waits out the same backoff window instead of each goroutine retrying independently and
+// piling onto the dependency in lockstep.
+type ConcurrentRetrier struct {
+	timer *ExpTimer
+
+	mu            sync.Mutex
+	nextAttemptAt time.Time
+}
+
+// NewConcurrentRetrier returns a ConcurrentRetrier backed by timer.
+func NewConcurrentRetrier(timer *ExpTimer) *ConcurrentRetrier {
+	return &ConcurrentRetrier{timer: timer}
+}
+
+// Throttle blocks until the shared backoff window opened by the last Failed call, if any, has
+// elapsed.
+func (r *ConcurrentRetrier) Throttle() {
+	r.mu.Lock()
+	wait := r.nextAttemptAt.Sub(r.timer.clock.Now())
+	r.mu.Unlock()
+	if wait > 0 {
+		r.timer.clock.Sleep(wait)
+	}
+}
+
+// Succeeded reports a successful call, resetting the shared backoff so the next failure starts
+// from the initial interval.
+func (r *ConcurrentRetrier) Succeeded() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextAttemptAt = time.Time{}
+	r.timer.Reset()
+}
+
+// Failed reports a failed call, opening a shared backoff window that blocks every caller in
+// Throttle. If err implements RetryableError, its RetryAfter hint takes priority over the
+// timer's own backoff when it is longer.
+func (r *ConcurrentRetrier) Failed(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.timer.clock.Now()
+	if now.Before(r.nextAttemptAt) {
+		// A backoff window is already open; let it run instead of advancing the timer again.
+		return
+	}
+
+	d := r.timer.NextDuration()
+	var retryAfter RetryableError
+	if errors.As(err, &retryAfter) {
+		if after := retryAfter.RetryAfter(); after > d {
+			d = after
+		}
+	}
+	r.nextAttemptAt = now.Add(d)
+}
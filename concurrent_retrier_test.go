@@ -0,0 +1,131 @@
+package retle
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shumon84/retle/retletest"
+)
+
+func TestConcurrentRetrier_Throttle(t *testing.T) {
+	fakeClock := retletest.NewFakeClock(time.Unix(0, 0))
+	retrier := NewConcurrentRetrier(NewExpTimer(time.Second, 1.0, WithClock(fakeClock)))
+
+	// 失敗を報告していないうちはThrottleは待たされない
+	done := make(chan struct{})
+	go func() {
+		retrier.Throttle()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("失敗を報告していないのにThrottleがブロックしました")
+	}
+
+	retrier.Failed(nil)
+
+	// 失敗を報告した直後のThrottleはバックオフ時間が経過するまでブロックする
+	done = make(chan struct{})
+	go func() {
+		retrier.Throttle()
+		close(done)
+	}()
+	fakeClock.BlockUntil(1)
+
+	select {
+	case <-done:
+		t.Fatal("バックオフ時間が経過する前にThrottleが完了しました")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	fakeClock.Advance(time.Second)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("バックオフ時間の経過後もThrottleが完了しませんでした")
+	}
+}
+
+func TestConcurrentRetrier_Succeeded(t *testing.T) {
+	fakeClock := retletest.NewFakeClock(time.Unix(0, 0))
+	retrier := NewConcurrentRetrier(NewExpTimer(time.Second, 1.0, WithClock(fakeClock)))
+
+	retrier.Failed(nil)
+	retrier.Succeeded()
+
+	// Succeededでバックオフ状態がリセットされるので、Throttleはブロックしない
+	done := make(chan struct{})
+	go func() {
+		retrier.Throttle()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Succeeded後もThrottleがブロックしました")
+	}
+}
+
+func TestConcurrentRetrier_Failed_DoesNotAdvanceWithinOpenWindow(t *testing.T) {
+	fakeClock := retletest.NewFakeClock(time.Unix(0, 0))
+	timer := NewExpTimer(time.Second, 2.0, WithClock(fakeClock))
+	retrier := NewConcurrentRetrier(timer)
+
+	// 既にバックオフ期間が開いている間に複数回Failedを呼んでもタイマーは一度しか進まない
+	retrier.Failed(nil)
+	retrier.Failed(nil)
+	retrier.Failed(nil)
+
+	done := make(chan struct{})
+	go func() {
+		retrier.Throttle()
+		close(done)
+	}()
+	fakeClock.BlockUntil(1)
+
+	fakeClock.Advance(999 * time.Millisecond)
+	select {
+	case <-done:
+		t.Fatal("バックオフ時間が経過する前にThrottleが完了しました")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	fakeClock.Advance(time.Millisecond)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("バックオフ時間の経過後もThrottleが完了しませんでした")
+	}
+}
+
+func TestConcurrentRetrier_Failed_RetryAfter(t *testing.T) {
+	fakeClock := retletest.NewFakeClock(time.Unix(0, 0))
+	retrier := NewConcurrentRetrier(NewExpTimer(time.Millisecond, 1.0, WithClock(fakeClock)))
+
+	retryAfter := time.Minute
+	retrier.Failed(NewRetryAfter(errors.New("sample error"), retryAfter))
+
+	done := make(chan struct{})
+	go func() {
+		retrier.Throttle()
+		close(done)
+	}()
+	fakeClock.BlockUntil(1)
+
+	// intervalよりも長いRetryAfterのほうが優先される
+	fakeClock.Advance(time.Millisecond)
+	select {
+	case <-done:
+		t.Fatal("RetryAfterの経過前にThrottleが完了しました")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	fakeClock.Advance(retryAfter)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("RetryAfterの経過後もThrottleが完了しませんでした")
+	}
+}
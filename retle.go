@@ -2,6 +2,9 @@ package retle
 
 import (
 	"context"
+	"errors"
+	"math/rand"
+	"sync"
 	"time"
 )
 
@@ -11,54 +14,196 @@ const (
 	DefaultMultiplier      = 1.5
 )
 
+// ErrMaxAttemptsExceeded is returned by Retry when the number of attempts configured via
+// WithMaxAttempts has been reached.
+var ErrMaxAttemptsExceeded = errors.New("retle: max attempts exceeded")
+
+// ErrMaxElapsedTimeExceeded is returned by Retry when the elapsed time configured via
+// WithMaxElapsedTime has passed.
+var ErrMaxElapsedTimeExceeded = errors.New("retle: max elapsed time exceeded")
+
 // RetryFunc is a type of retry function
 // first return value is bool that represent whether to retry
 // When first return value is false, second return value is used
 type RetryFunc func() (bool, error)
 
+// JitterMode determines how NextDuration randomizes the backoff interval it returns.
+type JitterMode int
+
+const (
+	// JitterNone disables jitter; NextDuration returns the deterministic backoff value.
+	JitterNone JitterMode = iota
+	// JitterFull returns a random duration in [0, base).
+	JitterFull
+	// JitterEqual returns a random duration in [base/2, base).
+	JitterEqual
+	// JitterDecorrelated grows the backoff from the previously returned duration instead of
+	// multiplying interval, following the AWS "decorrelated jitter" algorithm.
+	JitterDecorrelated
+)
+
+// Option configures an ExpTimer created by NewExpTimer.
+type Option func(*ExpTimer)
+
+// WithJitter sets the jitter mode used by NextDuration.
+func WithJitter(mode JitterMode) Option {
+	return func(e *ExpTimer) {
+		e.jitter = mode
+	}
+}
+
+// WithRandSource sets the source of randomness used to compute jitter, allowing deterministic
+// tests.
+func WithRandSource(src rand.Source) Option {
+	return func(e *ExpTimer) {
+		e.rand = rand.New(src)
+	}
+}
+
+// WithMaxInterval caps the duration returned by NextDuration to d.
+func WithMaxInterval(d time.Duration) Option {
+	return func(e *ExpTimer) {
+		e.maxInterval = d
+	}
+}
+
+// WithMaxElapsedTime makes Retry give up with ErrMaxElapsedTimeExceeded once d has passed since
+// the first call to retryFunc.
+func WithMaxElapsedTime(d time.Duration) Option {
+	return func(e *ExpTimer) {
+		e.maxElapsedTime = d
+	}
+}
+
+// WithMaxAttempts makes Retry give up with ErrMaxAttemptsExceeded once retryFunc has been called
+// n times.
+func WithMaxAttempts(n int) Option {
+	return func(e *ExpTimer) {
+		e.maxAttempts = n
+	}
+}
+
 // ExpTimer is a type to retry using exponential backoff algorithm
 type ExpTimer struct {
-	interval   time.Duration
-	multiplier float64
+	interval     time.Duration
+	multiplier   float64
+	initInterval time.Duration
+	prevInterval time.Duration
+	jitter       JitterMode
+
+	maxInterval    time.Duration
+	maxElapsedTime time.Duration
+	maxAttempts    int
+
+	clock      Clock
+	classifier Classifier
+
+	mu   sync.Mutex
+	rand *rand.Rand
 }
 
 // NewExpTimer return a ExpTimer instance
-func NewExpTimer(interval time.Duration, multiplier float64) *ExpTimer {
-	return &ExpTimer{
-		interval:   interval,
-		multiplier: multiplier,
+func NewExpTimer(interval time.Duration, multiplier float64, opts ...Option) *ExpTimer {
+	e := &ExpTimer{
+		interval:     interval,
+		multiplier:   multiplier,
+		initInterval: interval,
+		prevInterval: interval,
+		clock:        realClock{},
+		rand:         rand.New(rand.NewSource(time.Now().UnixNano())),
 	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
 }
 
 // DefaultExpTimer return a ExpTimer instance to use default option
-func DefaultExpTimer() *ExpTimer {
-	return NewExpTimer(DefaultInitialInterval, DefaultMultiplier)
+func DefaultExpTimer(opts ...Option) *ExpTimer {
+	return NewExpTimer(DefaultInitialInterval, DefaultMultiplier, opts...)
 }
 
 // NextDuration return a next backoff duration
 func (e *ExpTimer) NextDuration() time.Duration {
-	beforeInterval := e.interval
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	base := e.interval
 	e.interval = time.Duration(float64(e.interval) * e.multiplier)
-	return beforeInterval
+
+	var next time.Duration
+	switch e.jitter {
+	case JitterFull:
+		next = time.Duration(e.rand.Float64() * float64(base))
+	case JitterEqual:
+		next = base/2 + time.Duration(e.rand.Float64()*float64(base/2))
+	case JitterDecorrelated:
+		upperBound := e.prevInterval * 3
+		if e.maxInterval > 0 && e.maxInterval < upperBound {
+			upperBound = e.maxInterval
+		}
+		if span := upperBound - e.initInterval; span > 0 {
+			next = time.Duration(e.rand.Int63n(int64(span))) + e.initInterval
+		} else {
+			next = e.initInterval
+		}
+	default:
+		next = base
+	}
+
+	if e.jitter == JitterDecorrelated {
+		e.prevInterval = next
+	}
+	if e.maxInterval > 0 && next > e.maxInterval {
+		next = e.maxInterval
+	}
+	return next
+}
+
+// Reset restores the timer to its initial interval, clearing any state accumulated by
+// NextDuration such as decorrelated jitter's previous interval.
+func (e *ExpTimer) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.interval = e.initInterval
+	e.prevInterval = e.initInterval
 }
 
 // Sleep will sleep during NextDuration
 func (e *ExpTimer) Sleep() {
-	time.Sleep(e.NextDuration())
+	e.clock.Sleep(e.NextDuration())
 }
 
 // Retry calls retryFunc repeatedly according to exponential backoff algorithm
 func (e *ExpTimer) Retry(ctx context.Context, retryFunc RetryFunc) error {
+	startAt := e.clock.Now()
+	attempts := 0
 	for {
 		isRetry, err := retryFunc()
 		if !isRetry {
 			return err
 		}
+
+		attempts++
+		if e.maxAttempts > 0 && attempts >= e.maxAttempts {
+			return ErrMaxAttemptsExceeded
+		}
+		if e.maxElapsedTime > 0 && e.clock.Now().Sub(startAt) >= e.maxElapsedTime {
+			return ErrMaxElapsedTimeExceeded
+		}
+
+		d := e.NextDuration()
+		var retryAfter RetryableError
+		if errors.As(err, &retryAfter) {
+			if after := retryAfter.RetryAfter(); after > d {
+				d = after
+			}
+		}
+
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
-		default:
-			e.Sleep()
+		case <-e.clock.After(d):
 		}
 	}
 }
@@ -69,3 +214,29 @@ func Retry(ctx context.Context, retryFunc RetryFunc) error {
 	e := DefaultExpTimer()
 	return e.Retry(ctx, retryFunc)
 }
+
+// Retry2 calls fn repeatedly according to exponential backoff algorithm, using the configured
+// Classifier (see WithClassifier) to decide whether an error is retryable
+func (e *ExpTimer) Retry2(ctx context.Context, fn func() error) error {
+	return e.Retry(ctx, func() (bool, error) {
+		err := fn()
+		if err == nil {
+			return false, nil
+		}
+		switch e.classify(err) {
+		case ActionSucceed:
+			return false, nil
+		case ActionFail:
+			return false, err
+		default:
+			return true, err
+		}
+	})
+}
+
+// Retry2 calls fn repeatedly according to exponential backoff algorithm, using DefaultExpTimer
+// configured with opts
+func Retry2(ctx context.Context, fn func() error, opts ...Option) error {
+	e := DefaultExpTimer(opts...)
+	return e.Retry2(ctx, fn)
+}
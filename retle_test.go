@@ -3,17 +3,24 @@ package retle
 import (
 	"context"
 	"errors"
+	"math/rand"
 	"reflect"
 	"testing"
 	"time"
+
+	"github.com/shumon84/retle/retletest"
 )
 
 func TestNewExpTimer(t *testing.T) {
 	expectedExpTimer := &ExpTimer{
-		interval:   time.Second,
-		multiplier: 2.0,
+		interval:     time.Second,
+		multiplier:   2.0,
+		initInterval: time.Second,
+		prevInterval: time.Second,
+		clock:        realClock{},
 	}
 	actualExpTimer := NewExpTimer(time.Second, 2.0)
+	actualExpTimer.rand = nil // ランダムな内部状態を持つのでDeepEqualの対象から外す
 
 	if !reflect.DeepEqual(expectedExpTimer, actualExpTimer) {
 		t.Fail()
@@ -22,16 +29,111 @@ func TestNewExpTimer(t *testing.T) {
 
 func TestDefaultExpTimer(t *testing.T) {
 	expectedExpTimer := &ExpTimer{
-		interval:   DefaultInitialInterval,
-		multiplier: DefaultMultiplier,
+		interval:     DefaultInitialInterval,
+		multiplier:   DefaultMultiplier,
+		initInterval: DefaultInitialInterval,
+		prevInterval: DefaultInitialInterval,
+		clock:        realClock{},
 	}
 	actualExpTimer := DefaultExpTimer()
+	actualExpTimer.rand = nil // ランダムな内部状態を持つのでDeepEqualの対象から外す
 
 	if !reflect.DeepEqual(expectedExpTimer, actualExpTimer) {
 		t.Fail()
 	}
 }
 
+func TestExpTimer_NextDuration_Jitter(t *testing.T) {
+	testCases := []struct {
+		name   string
+		jitter JitterMode
+		check  func(t *testing.T, base, actual time.Duration)
+	}{
+		{
+			name:   "JitterFullは0以上base未満を返す",
+			jitter: JitterFull,
+			check: func(t *testing.T, base, actual time.Duration) {
+				if actual < 0 || actual >= base {
+					t.Fatalf("actual(%v) is out of [0, %v)", actual, base)
+				}
+			},
+		},
+		{
+			name:   "JitterEqualはbase/2以上base未満を返す",
+			jitter: JitterEqual,
+			check: func(t *testing.T, base, actual time.Duration) {
+				if actual < base/2 || actual >= base {
+					t.Fatalf("actual(%v) is out of [%v, %v)", actual, base/2, base)
+				}
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			expTimer := NewExpTimer(time.Second, 2.0, WithJitter(testCase.jitter), WithRandSource(rand.NewSource(1)))
+			base := time.Second
+			actual := expTimer.NextDuration()
+			testCase.check(t, base, actual)
+		})
+	}
+}
+
+func TestExpTimer_NextDuration_JitterDecorrelated(t *testing.T) {
+	expTimer := NewExpTimer(time.Second, 2.0, WithJitter(JitterDecorrelated), WithRandSource(rand.NewSource(1)))
+
+	prev := time.Second
+	for i := 0; i < 10; i++ {
+		actual := expTimer.NextDuration()
+		if actual < time.Second || actual >= prev*3 {
+			t.Fatalf("actual(%v) is out of [%v, %v)", actual, time.Second, prev*3)
+		}
+		prev = actual
+	}
+}
+
+func TestExpTimer_NextDuration_MaxInterval(t *testing.T) {
+	expTimer := NewExpTimer(time.Second, 2.0, WithMaxInterval(3*time.Second))
+
+	expected := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 3 * time.Second}
+	for _, want := range expected {
+		if actual := expTimer.NextDuration(); actual != want {
+			t.Fatalf("actual(%v) != want(%v)", actual, want)
+		}
+	}
+}
+
+func TestExpTimer_Retry_MaxAttempts(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	expTimer := NewExpTimer(time.Millisecond, 1.0, WithMaxAttempts(3))
+	attempts := 0
+	err := expTimer.Retry(ctx, func() (bool, error) {
+		attempts++
+		return true, nil // かならずリトライさせる
+	})
+	if err != ErrMaxAttemptsExceeded {
+		t.Fatal(err)
+	}
+	if attempts != 3 {
+		t.Fatalf("attempts(%d) != 3", attempts)
+	}
+}
+
+func TestExpTimer_Retry_MaxElapsedTime(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	expTimer := NewExpTimer(50*time.Millisecond, 1.0, WithMaxElapsedTime(100*time.Millisecond))
+	err := expTimer.Retry(ctx, func() (bool, error) {
+		return true, nil // かならずリトライさせる
+	})
+	if err != ErrMaxElapsedTimeExceeded {
+		t.Fatal(err)
+	}
+}
+
 func TestExpTimer_NextDuration(t *testing.T) {
 	expTimer := NewExpTimer(1, 2)
 	actual := int64(1)
@@ -163,6 +265,96 @@ func TestExpTimer_Retry(t *testing.T) {
 	}
 }
 
+func TestExpTimer_Retry_RetryAfter(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	// intervalよりもRetryAfterのほうが長いので、そちらが優先される
+	expTimer := NewExpTimer(10*time.Millisecond, 1.0)
+	retryAfter := 100 * time.Millisecond
+
+	called := false
+	startAt := time.Now()
+	err := expTimer.Retry(ctx, func() (bool, error) {
+		if called {
+			return false, nil
+		}
+		called = true
+		return true, NewRetryAfter(errors.New("too many requests"), retryAfter)
+	})
+	elapsed := time.Since(startAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed < retryAfter {
+		t.Fatalf("elapsed(%v) is shorter than RetryAfter(%v)", elapsed, retryAfter)
+	}
+}
+
+func TestExpTimer_Retry_FakeClock(t *testing.T) {
+	fakeClock := retletest.NewFakeClock(time.Unix(0, 0))
+	expTimer := NewExpTimer(time.Second, 2.0, WithClock(fakeClock))
+	durationTimer := NewExpTimer(time.Second, 2.0) // 実際に発生するはずのスリープ時間を計算するための時計
+
+	retryLimit := 5
+	retryCount := 0
+	done := make(chan error, 1)
+	go func() {
+		done <- expTimer.Retry(context.Background(), func() (bool, error) {
+			retryCount++
+			if retryCount > retryLimit {
+				return false, nil
+			}
+			return true, nil
+		})
+	}()
+
+	// Retryがスリープに入るのを待ってから時計を進める、を繰り返す
+	for i := 0; i < retryLimit; i++ {
+		fakeClock.BlockUntil(1)
+		fakeClock.Advance(durationTimer.NextDuration())
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Retryが完了しませんでした")
+	}
+	if retryCount != retryLimit+1 {
+		t.Fatalf("retryCount(%d) != %d", retryCount, retryLimit+1)
+	}
+}
+
+func TestExpTimer_Retry_FakeClock_MaxElapsedTime(t *testing.T) {
+	fakeClock := retletest.NewFakeClock(time.Unix(0, 0))
+	// 実時間では長すぎて現実的にテストできないMaxElapsedTimeも、FakeClockなら一瞬で検証できる
+	expTimer := NewExpTimer(time.Hour, 1.0, WithClock(fakeClock), WithMaxElapsedTime(3*time.Hour))
+
+	done := make(chan error, 1)
+	go func() {
+		done <- expTimer.Retry(context.Background(), func() (bool, error) {
+			return true, nil
+		})
+	}()
+
+	for i := 0; i < 3; i++ {
+		fakeClock.BlockUntil(1)
+		fakeClock.Advance(time.Hour)
+	}
+
+	select {
+	case err := <-done:
+		if err != ErrMaxElapsedTimeExceeded {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Retryが完了しませんでした")
+	}
+}
+
 func TestRetry(t *testing.T) {
 	testCases := []struct {
 		name string
@@ -0,0 +1,30 @@
+// Package retlegrpc provides retle.Classifier helpers for gRPC errors, kept out of the core
+// retle package so that importing it does not pull in google.golang.org/grpc as a hard
+// dependency.
+package retlegrpc
+
+import (
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/shumon84/retle"
+)
+
+// ClassifyCode returns a retle.Classifier that retries errors whose gRPC status code is one of
+// retryable.
+func ClassifyCode(retryable ...codes.Code) retle.Classifier {
+	set := make(map[codes.Code]struct{}, len(retryable))
+	for _, code := range retryable {
+		set[code] = struct{}{}
+	}
+	return func(err error) retle.Action {
+		if _, ok := set[status.Code(err)]; ok {
+			return retle.ActionRetry
+		}
+		return retle.ActionFail
+	}
+}
+
+// ClassifyUnavailableOrResourceExhausted retries the gRPC codes commonly returned by an
+// overloaded or temporarily unreachable backend.
+var ClassifyUnavailableOrResourceExhausted = ClassifyCode(codes.Unavailable, codes.ResourceExhausted)
@@ -0,0 +1,21 @@
+package retlegrpc
+
+import (
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/shumon84/retle"
+)
+
+func TestClassifyCode(t *testing.T) {
+	classifier := ClassifyCode(codes.Unavailable, codes.ResourceExhausted)
+
+	if action := classifier(status.Error(codes.Unavailable, "unavailable")); action != retle.ActionRetry {
+		t.Fatalf("action(%v) != ActionRetry", action)
+	}
+	if action := classifier(status.Error(codes.NotFound, "not found")); action != retle.ActionFail {
+		t.Fatalf("action(%v) != ActionFail", action)
+	}
+}
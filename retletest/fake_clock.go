@@ -0,0 +1,85 @@
+// Package retletest provides test doubles for the retle package.
+package retletest
+
+import (
+	"sync"
+	"time"
+)
+
+// FakeClock is a retle.Clock implementation whose time only advances when Advance is called,
+// making retry, cancellation, and timeout tests deterministic and instant.
+type FakeClock struct {
+	mu      sync.Mutex
+	cond    *sync.Cond
+	now     time.Time
+	waiters []fakeClockWaiter
+}
+
+type fakeClockWaiter struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+// NewFakeClock returns a FakeClock whose current time is now.
+func NewFakeClock(now time.Time) *FakeClock {
+	c := &FakeClock{now: now}
+	c.cond = sync.NewCond(&c.mu)
+	return c
+}
+
+// Now returns the fake clock's current time.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Sleep blocks until the fake clock has been advanced by at least d.
+func (c *FakeClock) Sleep(d time.Duration) {
+	<-c.After(d)
+}
+
+// After returns a channel that receives the deadline time once the fake clock has been advanced
+// to or beyond it.
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	deadline := c.now.Add(d)
+	if !deadline.After(c.now) {
+		ch <- deadline
+		return ch
+	}
+	c.waiters = append(c.waiters, fakeClockWaiter{deadline: deadline, ch: ch})
+	c.cond.Broadcast()
+	return ch
+}
+
+// BlockUntil blocks until at least n goroutines are waiting on After or Sleep, so a test can
+// call Advance only once it is sure the code under test has registered its timer.
+func (c *FakeClock) BlockUntil(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(c.waiters) < n {
+		c.cond.Wait()
+	}
+}
+
+// Advance moves the fake clock forward by d, firing any pending After/Sleep channels whose
+// deadline is at or before the new time.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	remaining := c.waiters[:0]
+	for _, w := range c.waiters {
+		if !w.deadline.After(c.now) {
+			w.ch <- w.deadline
+		} else {
+			remaining = append(remaining, w)
+		}
+	}
+	c.waiters = remaining
+}
@@ -0,0 +1,34 @@
+package retle
+
+import "time"
+
+// RetryableError is an error that carries a hint about how long the caller should wait before
+// retrying, such as an HTTP Retry-After header or a gRPC ResourceExhausted delay.
+type RetryableError interface {
+	error
+	// RetryAfter returns the minimum duration to wait before the next attempt.
+	RetryAfter() time.Duration
+}
+
+// retryAfterError wraps an error with a RetryAfter duration.
+type retryAfterError struct {
+	err        error
+	retryAfter time.Duration
+}
+
+// NewRetryAfter wraps err so that Retry sleeps at least d before the next attempt.
+func NewRetryAfter(err error, d time.Duration) error {
+	return &retryAfterError{err: err, retryAfter: d}
+}
+
+func (e *retryAfterError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryAfterError) Unwrap() error {
+	return e.err
+}
+
+func (e *retryAfterError) RetryAfter() time.Duration {
+	return e.retryAfter
+}